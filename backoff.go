@@ -0,0 +1,43 @@
+package xxl
+
+import (
+	"math/rand"
+	"time"
+)
+
+// backoff 指数退避计数器,每次失败后next()翻倍并叠加随机抖动,避免大量执行器
+// 同时掉线重连时对调度中心/注册中心造成雪崩式的请求尖峰(thundering herd)
+type backoff struct {
+	base    time.Duration
+	max     time.Duration
+	current time.Duration
+}
+
+func newBackoff(base, max time.Duration) *backoff {
+	return &backoff{base: base, max: max, current: base}
+}
+
+// next 返回下一次重试的等待时间,并将退避窗口翻倍
+func (b *backoff) next() time.Duration {
+	d := b.current
+	b.current *= 2
+	if b.current > b.max {
+		b.current = b.max
+	}
+	return d/2 + jitter(d/2)
+}
+
+// reset 注册成功后重置退避窗口
+func (b *backoff) reset() {
+	b.current = b.base
+}
+
+// jitter 返回[0, max)之间的随机时长,max<=0时不抖动。用于给固定周期的定时器
+// (如注册失败重试、心跳续约)叠加随机量,避免大量执行器同一时刻集中请求造成
+// 雪崩式的流量尖峰(thundering herd)
+func jitter(max time.Duration) time.Duration {
+	if max <= 0 {
+		return 0
+	}
+	return time.Duration(rand.Int63n(int64(max)))
+}
@@ -9,9 +9,13 @@ import (
 	"os"
 	"os/signal"
 	"strings"
-	"sync"
 	"syscall"
 	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // Executor 执行器
@@ -20,8 +24,15 @@ type Executor interface {
 	Init(...Option)
 	// LogHandler 日志查询
 	LogHandler(handler LogHandler)
+	// UseLogStore 使用文件日志存储,任务日志查询与TaskLogger注入均基于此store
+	UseLogStore(store *FileLogStore)
+	// UseRemoteLogStore 使用自定义的RemoteLogStore(如S3/OSS等远程对象存储)接管日志查询
+	// 并为任务注入TaskLogger,用于FileLogStore之外的日志存储后端
+	UseRemoteLogStore(store RemoteLogStore)
 	// RegTask 注册任务
 	RegTask(pattern string, task TaskFunc)
+	// RegShardTask 注册分片任务,自动解析调度中心下发的广播分片参数
+	RegShardTask(pattern string, task func(ctx context.Context, index, total int) error)
 	// RunTask 运行任务
 	RunTask(writer http.ResponseWriter, request *http.Request)
 	// KillTask 杀死任务
@@ -54,12 +65,23 @@ func newExecutor(opts ...Option) *executor {
 type executor struct {
 	opts    Options
 	address string
-	regList *taskList //注册任务列表
-	runList *taskList //正在执行任务列表
-	mu      sync.RWMutex
+	regList *taskList  //注册任务列表
+	runList *taskList  //正在执行任务列表
+	jobMu   keyedMutex //按JobID分段加锁,取代全局锁
 	log     Logger
 
-	logHandler LogHandler //日志查询handler
+	pool *workerPool //有界任务执行worker池,nil表示不限制并发
+
+	logHandler LogHandler     //日志查询handler
+	logStore   RemoteLogStore //日志存储(FileLogStore或用户自定义实现),非nil时为任务注入TaskLogger
+
+	metrics *metrics    //Prometheus指标
+	debug   *debugTasks //运行中任务快照,供/debug/tasks查询
+
+	alerts *alertDispatcher //告警通知fan-out
+
+	glue   *glueRegistry //GLUE模式动态脚本注册表
+	serial *serialQueue  //SERIAL_EXECUTION策略的有界FIFO队列
 }
 
 func (e *executor) Init(opts ...Option) {
@@ -74,14 +96,52 @@ func (e *executor) Init(opts ...Option) {
 		data: make(map[string]*Task),
 	}
 	e.address = e.opts.ExecutorIp + ":" + e.opts.ExecutorPort
+	if e.opts.metricsRegistry == nil {
+		e.opts.metricsRegistry = prometheus.NewRegistry()
+	}
+	e.metrics = newMetrics(e.opts.metricsRegistry)
+	e.debug = newDebugTasks()
+	e.alerts = newAlertDispatcher(e.opts.alertWindow, e.resolveNotifiers()...)
+	e.glue = newGlueRegistry()
+	e.serial = newSerialQueue(defaultSerialQueueSize)
+	e.pool = newWorkerPool(e.opts.maxConcurrent, e.opts.queueSize)
 	go e.registry()
 }
 
+// resolveNotifiers 返回用户配置的Notifier,未配置WithNotifiers但配置了
+// NotifyWebhook/NotifySecret时回退到默认的DingTalkNotifier,保持向后兼容
+func (e *executor) resolveNotifiers() []Notifier {
+	if len(e.opts.notifiers) > 0 {
+		return e.opts.notifiers
+	}
+	if e.opts.NotifyWebhook != "" && e.opts.NotifySecret != "" {
+		return []Notifier{&DingTalkNotifier{Webhook: e.opts.NotifyWebhook, Secret: e.opts.NotifySecret}}
+	}
+	return nil
+}
+
 // LogHandler 日志handler
 func (e *executor) LogHandler(handler LogHandler) {
 	e.logHandler = handler
 }
 
+// UseLogStore 使用文件日志存储,自动接管日志查询并为任务注入TaskLogger
+func (e *executor) UseLogStore(store *FileLogStore) {
+	e.logStore = store
+	store.running = func(jobID int64) bool {
+		return e.runList.Exists(Int64ToStr(jobID)) //jobID仍在runList中即视为对应任务尚未结束
+	}
+	e.logHandler = store.LogHandler
+}
+
+// UseRemoteLogStore 使用自定义的RemoteLogStore(如S3/OSS等远程对象存储)接管日志查询
+// 并为任务注入TaskLogger。与UseLogStore不同,这里不具备FileLogStore那样基于runList的
+// IsEnd存活判断,以及空闲句柄回收/滚动压缩/保留清理能力,均由具体实现自行负责
+func (e *executor) UseRemoteLogStore(store RemoteLogStore) {
+	e.logStore = store
+	e.logHandler = store.LogHandler
+}
+
 func (e *executor) Run() (err error) {
 	// 创建路由器
 	mux := http.NewServeMux()
@@ -92,6 +152,8 @@ func (e *executor) Run() (err error) {
 	mux.HandleFunc("/beat", e.beat)
 	mux.HandleFunc("/ping", e.ping)
 	mux.HandleFunc("/idleBeat", e.idleBeat)
+	mux.Handle(e.opts.metricsPath, promhttp.HandlerFor(e.opts.metricsRegistry, promhttp.HandlerOpts{}))
+	mux.HandleFunc("/debug/tasks", e.debugTasksHandler)
 	// 创建服务器
 	server := &http.Server{
 		Addr:         ":" + e.opts.ExecutorPort,
@@ -104,11 +166,12 @@ func (e *executor) Run() (err error) {
 	quit := make(chan os.Signal)
 	signal.Notify(quit, syscall.SIGKILL, syscall.SIGQUIT, syscall.SIGINT, syscall.SIGTERM)
 	<-quit
-	e.registryRemove()
+	e.Stop()
 	return nil
 }
 
 func (e *executor) Stop() {
+	e.pool.Close() //唤醒worker池中阻塞的worker goroutine,令其随tasks关闭而退出
 	e.registryRemove()
 }
 
@@ -122,9 +185,6 @@ func (e *executor) RegTask(pattern string, task TaskFunc) {
 
 // 运行一个任务
 func (e *executor) runTask(writer http.ResponseWriter, request *http.Request) {
-	e.mu.Lock()
-	defer e.mu.Unlock()
-
 	req, _ := ioutil.ReadAll(request.Body)
 	param := &RunReq{}
 	err := json.Unmarshal(req, &param)
@@ -134,6 +194,15 @@ func (e *executor) runTask(writer http.ResponseWriter, request *http.Request) {
 		return
 	}
 	e.log.Info("task params: %+v", param)
+
+	defer e.jobMu.Lock(Int64ToStr(param.JobID))() //按JobID加锁,避免无关Job相互阻塞
+
+	//GLUE模式:调度中心动态下发脚本源码,更新后以脚本handler注册,复用普通TaskFunc的全部流程
+	if glueType := GlueType(param.GlueType); glueType == GlueShell || glueType == GluePython {
+		e.glue.Update(param.ExecutorHandler, glueType, param.GlueSource)
+		e.RegTask(param.ExecutorHandler, e.glueTaskFunc(param.ExecutorHandler))
+	}
+
 	if !e.regList.Exists(param.ExecutorHandler) {
 		_, _ = writer.Write(returnCall(param, FailureCode, "Task not registered"))
 		e.log.Error("task not registered:" + param.ExecutorHandler)
@@ -142,21 +211,61 @@ func (e *executor) runTask(writer http.ResponseWriter, request *http.Request) {
 
 	//阻塞策略处理
 	if e.runList.Exists(Int64ToStr(param.JobID)) {
-		if param.ExecutorBlockStrategy == coverEarly { //覆盖之前调度
+		switch param.ExecutorBlockStrategy {
+		case coverEarly: //覆盖之前调度
 			oldTask := e.runList.Get(Int64ToStr(param.JobID))
 			if oldTask != nil {
 				oldTask.Cancel()
 				e.runList.Del(Int64ToStr(oldTask.Id))
 			}
-		} else { //单机串行,丢弃后续调度 都进行阻塞
+		case serialExecution: //单机串行,后续调度进入有界FIFO队列,等待当前任务结束后依次执行
+			if e.serial.push(Int64ToStr(param.JobID), param) {
+				_, _ = writer.Write(returnGeneral())
+			} else {
+				_, _ = writer.Write(returnCall(param, FailureCode, "serial queue full"))
+				e.log.Error("serial queue full:" + param.ExecutorHandler)
+			}
+			return
+		case discardLater: //丢弃后续调度,保留当前运行的任务
+			_, _ = writer.Write(returnCall(param, FailureCode, "There are tasks running"))
+			e.log.Error("task already running:" + param.ExecutorHandler)
+			return
+		default: //未知策略,按discardLater的语义处理,丢弃后续调度
 			_, _ = writer.Write(returnCall(param, FailureCode, "There are tasks running"))
 			e.log.Error("task already running:" + param.ExecutorHandler)
 			return
 		}
 	}
 
+	if !e.dispatchTask(param, request) {
+		writer.WriteHeader(http.StatusTooManyRequests)
+		_, _ = writer.Write(returnCall(param, FailureCode, "executor worker pool saturated, retry another executor"))
+		e.log.Error("worker pool saturated:" + param.ExecutorHandler)
+		return
+	}
+	e.log.Info("task[" + Int64ToStr(param.JobID) + "] start:" + param.ExecutorHandler)
+	_, _ = writer.Write(returnGeneral())
+}
+
+// dispatchTask 启动一次任务执行,供runTask的正常调度及serial队列的排队调度共用,
+// 返回false表示worker池已饱和(并发槽位与队列均已占满),调用方应拒绝该次调度。
+// request可为nil(串行队列排队触发时没有原始HTTP请求,不做trace上下文提取)
+func (e *executor) dispatchTask(param *RunReq, request *http.Request) bool {
 	cxt := context.WithValue(context.Background(), "trace_id", fmt.Sprintf("%s:%d", param.ExecutorHandler, param.JobID))
-	task := e.regList.Get(param.ExecutorHandler)
+	if request != nil {
+		cxt = extractTraceContext(cxt, request) //优先提取调度中心透传的W3C HTTP trace头
+	}
+	if !trace.SpanContextFromContext(cxt).IsValid() {
+		//原生admin不会下发W3C trace头,退化从ExecutorParams中提取(若调度中心透传了的话)
+		cxt = extractTraceFromParams(cxt, param.ExecutorParams)
+	}
+	cxt, span := e.startTaskSpan(cxt, param)
+	//regList.Get返回的是RegTask时注册的、按handler名长期共享的*Task模板:同一handler
+	//可能被admin配置了多个JobID的Job,按JobID分段的jobMu并不能阻止这些不同JobID的
+	//并发dispatch相互踩踏同一个模板对象的字段,因此这里必须克隆出一份仅归本次调度
+	//使用的*Task,只从模板继承fn,其余字段在下面按本次调度参数重新赋值
+	tmpl := e.regList.Get(param.ExecutorHandler)
+	task := &Task{fn: tmpl.fn}
 	if param.ExecutorTimeout > 0 {
 		task.Ext, task.Cancel = context.WithTimeout(cxt, time.Duration(param.ExecutorTimeout)*time.Second)
 	} else {
@@ -166,27 +275,81 @@ func (e *executor) runTask(writer http.ResponseWriter, request *http.Request) {
 	task.Name = param.ExecutorHandler
 	task.Param = param
 	task.log = e.log
+	if e.logStore != nil {
+		task.Ext = WithTaskLogger(task.Ext, &TaskLogger{store: e.logStore, jobID: param.JobID, logID: param.LogID})
+	}
+	task.Ext = withShardInfo(task.Ext, param.BroadcastIndex, param.BroadcastTotal)
 
 	e.runList.Set(Int64ToStr(task.Id), task)
 
+	e.metrics.tasksStarted.WithLabelValues(task.Name).Inc()
+	e.metrics.tasksRunning.WithLabelValues(task.Name).Inc()
+	startAt := time.Now()
+	var deadline time.Time
+	if d, ok := task.Ext.Deadline(); ok {
+		deadline = d
+	}
+	debugKey := Int64ToStr(task.Id)
+	e.debug.add(debugKey, &runningTask{
+		JobID:    task.Id,
+		Handler:  task.Name,
+		StartAt:  startAt,
+		Deadline: deadline,
+		TraceID:  span.SpanContext().TraceID().String(),
+	})
+	finish := func(code int64, msg string) {
+		result := "success"
+		if code != SuccessCode {
+			result = "fail"
+		}
+		e.metrics.tasksFinished.WithLabelValues(task.Name, result).Inc()
+		e.metrics.taskDuration.WithLabelValues(task.Name).Observe(time.Since(startAt).Seconds())
+		e.metrics.tasksRunning.WithLabelValues(task.Name).Dec()
+		e.debug.remove(debugKey)
+	}
+
 	notify := func(message string) {
 		message = fmt.Sprintf("task timeout alert\n%s", message)
-		Alert(e.opts.NotifyWebhook, e.opts.NotifySecret, message, true)
+		e.alerts.dispatch(EventTimeout, message)
 	}
-	go task.Run(notify, func(code int64, msg string) {
-		e.callback(task, code, msg)
-	})
-	e.log.Info("task[" + Int64ToStr(param.JobID) + "] start:" + param.ExecutorHandler)
-	_, _ = writer.Write(returnGeneral())
+	run := func() {
+		defer func() {
+			if r := recover(); r != nil {
+				endTaskSpan(span, FailureCode, fmt.Sprintf("panic: %v", r), r)
+				finish(FailureCode, "panic")
+				e.callback(task, FailureCode, fmt.Sprintf("task panic: %v", r))
+			}
+		}()
+		task.Run(notify, func(code int64, msg string) {
+			endTaskSpan(span, code, msg, nil)
+			finish(code, msg)
+			e.callback(task, code, msg)
+		})
+	}
+
+	if e.pool == nil {
+		go run()
+		return true
+	}
+	if !e.pool.Submit(run) {
+		// 池已饱和,回滚本次调度占用的状态,让调用方拒绝该次/run请求
+		e.runList.Del(Int64ToStr(task.Id))
+		e.metrics.tasksRunning.WithLabelValues(task.Name).Dec()
+		e.debug.remove(debugKey)
+		span.End()
+		return false
+	}
+	return true
 }
 
 // 删除一个任务
 func (e *executor) killTask(writer http.ResponseWriter, request *http.Request) {
-	e.mu.Lock()
-	defer e.mu.Unlock()
 	req, _ := ioutil.ReadAll(request.Body)
 	param := &killReq{}
 	_ = json.Unmarshal(req, &param)
+
+	defer e.jobMu.Lock(Int64ToStr(param.JobID))()
+
 	if !e.runList.Exists(Int64ToStr(param.JobID)) {
 		_, _ = writer.Write(returnKill(param, FailureCode))
 		e.log.Error("task not running:" + Int64ToStr(param.JobID))
@@ -195,6 +358,7 @@ func (e *executor) killTask(writer http.ResponseWriter, request *http.Request) {
 	task := e.runList.Get(Int64ToStr(param.JobID))
 	task.Cancel()
 	e.runList.Del(Int64ToStr(param.JobID))
+	e.metrics.killTotal.WithLabelValues(task.Name).Inc()
 	_, _ = writer.Write(returnGeneral())
 }
 
@@ -238,8 +402,6 @@ func (e *executor) ping(writer http.ResponseWriter, request *http.Request) {
 
 // 忙碌检测
 func (e *executor) idleBeat(writer http.ResponseWriter, request *http.Request) {
-	e.mu.Lock()
-	defer e.mu.Unlock()
 	defer request.Body.Close()
 	req, _ := ioutil.ReadAll(request.Body)
 	param := &idleBeatReq{}
@@ -249,6 +411,9 @@ func (e *executor) idleBeat(writer http.ResponseWriter, request *http.Request) {
 		e.log.Error("params err:" + string(req))
 		return
 	}
+
+	defer e.jobMu.Lock(Int64ToStr(param.JobID))()
+
 	if e.runList.Exists(Int64ToStr(param.JobID)) {
 		_, _ = writer.Write(returnIdleBeat(FailureCode))
 		e.log.Error("idleBeat task[" + Int64ToStr(param.JobID) + "] running")
@@ -258,78 +423,82 @@ func (e *executor) idleBeat(writer http.ResponseWriter, request *http.Request) {
 	_, _ = writer.Write(returnGeneral())
 }
 
-// 注册执行器到调度中心
+// 注册执行器到调度中心,20秒心跳续约,断连时按指数退避+抖动重试,
+// 若Registrar支持Watch,收到租约失效/连接断开事件后同样按指数退避+抖动重新注册
 func (e *executor) registry() {
-
-	t := time.NewTimer(time.Second * 0) //初始立即执行
-	defer t.Stop()
-	req := &Registry{
-		RegistryGroup: "EXECUTOR",
-		RegistryKey:   e.opts.RegistryKey,
-		RegistryValue: "http://" + e.address,
+	if e.opts.registrar == nil {
+		e.opts.registrar = newHTTPRegistrar(e)
 	}
-	param, err := json.Marshal(req)
-	if err != nil {
-		e.log.Error("executor registry info parse failed:" + err.Error())
+	value := "http://" + e.address
+	ctx := context.Background()
+
+	register := func() bool {
+		spanCtx, span := e.startRegistrySpan(ctx, "register")
+		defer span.End()
+		if err := e.opts.registrar.Register(spanCtx, e.opts.RegistryKey, value); err != nil {
+			span.SetStatus(codes.Error, err.Error())
+			e.metrics.registryHeartbeats.WithLabelValues("fail").Inc()
+			e.log.Error("executor registry failed:" + err.Error())
+			return false
+		}
+		e.metrics.registryHeartbeats.WithLabelValues("success").Inc()
+		e.log.Info("executor registry success")
+		return true
 	}
+
+	backoff := newBackoff(time.Second, time.Second*20)
+	t := time.NewTimer(0) //初始立即执行
+	defer t.Stop()
 	for {
 		<-t.C
-		t.Reset(time.Second * time.Duration(20)) //20秒心跳防止过期
-		func() {
-			result, err := e.post("/api/registry", string(param))
-			if err != nil {
-				e.log.Error("executor registry failed1:" + err.Error())
-				return
-			}
-			defer result.Body.Close()
-			body, err := ioutil.ReadAll(result.Body)
-			if err != nil {
-				e.log.Error("executor registry failed2:" + err.Error())
-				return
-			}
-			res := &res{}
-			_ = json.Unmarshal(body, &res)
-			if res.Code != SuccessCode {
-				e.log.Error("executor registry failed3:" + string(body))
-				return
+		if register() {
+			backoff.reset()
+			if watch := e.opts.registrar.Watch(ctx); watch != nil {
+				<-watch // 阻塞直到租约失效/连接断开,触发重新注册
+				//续约失败后走与注册失败相同的指数退避+抖动,而非立即重试:若失败原因
+				//并非租约彻底丢失而是短暂的网络抖动,register()往往会再次成功,
+				//随后Watch又很快失败,t.Reset(0)会让这里变成无退避的空转死循环
+				t.Reset(backoff.next())
+				continue
 			}
-			e.log.Info("executor registry success:" + string(body))
-		}()
-
+			t.Reset(time.Second*20 + jitter(time.Second*4)) //20秒心跳防止过期,叠加抖动避免雪崩
+			continue
+		}
+		t.Reset(backoff.next()) //注册失败,指数退避+抖动重试
 	}
 }
 
 // 执行器注册摘除
 func (e *executor) registryRemove() {
-	t := time.NewTimer(time.Second * 0) //初始立即执行
-	defer t.Stop()
-	req := &Registry{
-		RegistryGroup: "EXECUTOR",
-		RegistryKey:   e.opts.RegistryKey,
-		RegistryValue: "http://" + e.address,
+	if e.opts.registrar == nil {
+		e.opts.registrar = newHTTPRegistrar(e)
 	}
-	param, err := json.Marshal(req)
-	if err != nil {
-		e.log.Error("executor remove failed:" + err.Error())
-		return
-	}
-	res, err := e.post("/api/registryRemove", string(param))
-	if err != nil {
+	if err := e.opts.registrar.Unregister(context.Background(), e.opts.RegistryKey, "http://"+e.address); err != nil {
 		e.log.Error("executor remove failed:" + err.Error())
 		return
 	}
-	defer res.Body.Close()
-	body, err := ioutil.ReadAll(res.Body)
-	e.log.Info("executor remove success:" + string(body))
+	e.log.Info("executor remove success")
 }
 
 // 回调任务列表
 func (e *executor) callback(task *Task, code int64, msg string) {
 	e.runList.Del(Int64ToStr(task.Id))
+	// runList已摘除当前任务后,再从串行队列取出排队任务接力执行,dispatchTask会
+	// 以同一JobID重新Set进runList。顺序不能颠倒:若先接力dispatch再Del,会把
+	// 刚启动的新任务的runList记录误删,导致/kill与/idleBeat误判该Job未在运行
+	if next, ok := e.serial.pop(Int64ToStr(task.Id)); ok {
+		if !e.dispatchTask(next, nil) {
+			e.log.Error("worker pool saturated, drop queued task:" + next.ExecutorHandler)
+		}
+	}
+	_, span := e.startCallbackSpan(task.Ext, task)
+	defer span.End()
 	res, err := e.post("/api/callback", string(returnCall(task.Param, code, msg)))
 	if err != nil {
+		span.SetStatus(codes.Error, err.Error())
+		e.metrics.callbackFailures.Inc()
 		message := fmt.Sprintf("task callback failed alert\ntaskID: %d\ntask name: %s\ntask params: %s\nfailed reason: %s", task.Id, task.Name, task.Param.ExecutorParams, err.Error())
-		Alert(e.opts.NotifyWebhook, e.opts.NotifySecret, message, true)
+		e.alerts.dispatch(EventCallbackFailure, message)
 		e.log.Error("callback err : ", err.Error())
 		return
 	}
@@ -343,7 +512,7 @@ func (e *executor) callback(task *Task, code int64, msg string) {
 	lowerMsg := strings.ToLower(msg)
 	if strings.Contains(lowerMsg, "fail") || strings.Contains(lowerMsg, "error") {
 		message := fmt.Sprintf("task execution failed alert\ntaskID: %d\ntask name: %s\ntask params: %s\nfailed reason: %s", task.Id, task.Name, task.Param.ExecutorParams, msg)
-		Alert(e.opts.NotifyWebhook, e.opts.NotifySecret, message, true)
+		e.alerts.dispatch(EventExecutionFailure, message)
 	}
 	e.log.Info("task callback success:" + string(body))
 }
@@ -0,0 +1,82 @@
+package xxl
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// testLogger是测试用的no-op Logger实现
+type testLogger struct{}
+
+func (testLogger) Info(format string, v ...interface{})  {}
+func (testLogger) Error(format string, v ...interface{}) {}
+
+// TestRunTaskDiscardLaterBlockStrategy验证DISCARD_LATER阻塞策略下,调度中心对一个
+// 仍在运行的JobID重复下发调度时会被拒绝,且runList中原有的任务不受影响,覆盖
+// discardLater从隐式走default分支改为显式case之后行为不变
+func TestRunTaskDiscardLaterBlockStrategy(t *testing.T) {
+	e := newExecutor(SetLogger(testLogger{}))
+	e.Init()
+	e.RegTask("demo", func(ctx context.Context, param *RunReq) string { return "success" })
+
+	const jobID = int64(42)
+	e.runList.Set(Int64ToStr(jobID), &Task{Id: jobID, Name: "demo"})
+
+	body, _ := json.Marshal(&RunReq{JobID: jobID, ExecutorHandler: "demo", ExecutorBlockStrategy: discardLater})
+	req := httptest.NewRequest(http.MethodPost, "/run", bytes.NewReader(body))
+	w := httptest.NewRecorder()
+	e.RunTask(w, req)
+
+	if !strings.Contains(w.Body.String(), "There are tasks running") {
+		t.Fatalf("expected rejection response, got: %s", w.Body.String())
+	}
+	if task := e.runList.Get(Int64ToStr(jobID)); task == nil || task.Name != "demo" {
+		t.Fatalf("expected original running task to be left untouched, got %+v", task)
+	}
+}
+
+// TestDispatchTaskClonesTemplatePerJobID验证同一handler被多个JobID共用时(一个
+// @XxlJob对应多个admin Job配置是常见场景),并发dispatch互不干扰地各自拿到正确的
+// Id/Name,而不是相互覆盖regList中那个按handler名长期共享的*Task模板
+func TestDispatchTaskClonesTemplatePerJobID(t *testing.T) {
+	e := newExecutor(SetLogger(testLogger{}))
+	e.Init()
+	//handler阻塞到断言结束,否则任务完成后callback会把runList中的记录摘除
+	release := make(chan struct{})
+	defer close(release)
+	e.RegTask("shared-handler", func(ctx context.Context, param *RunReq) string { <-release; return "success" })
+
+	ids := []int64{1001, 1002}
+	var wg sync.WaitGroup
+	for _, id := range ids {
+		wg.Add(1)
+		go func(id int64) {
+			defer wg.Done()
+			param := &RunReq{JobID: id, ExecutorHandler: "shared-handler"}
+			if !e.dispatchTask(param, nil) {
+				t.Errorf("dispatchTask(%d) unexpectedly rejected", id)
+			}
+		}(id)
+	}
+	wg.Wait()
+
+	for _, id := range ids {
+		task := e.runList.Get(Int64ToStr(id))
+		if task == nil {
+			t.Errorf("runList missing entry for JobID %d", id)
+			continue
+		}
+		if task.Id != id {
+			t.Errorf("task.Id = %d, want %d (template shared/mutated across JobIDs)", task.Id, id)
+		}
+		if task.Name != "shared-handler" {
+			t.Errorf("task.Name = %q, want shared-handler", task.Name)
+		}
+	}
+}
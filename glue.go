@@ -0,0 +1,148 @@
+package xxl
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+)
+
+// GlueType GLUE模式类型,对应调度中心动态下发的脚本语言
+type GlueType string
+
+const (
+	GlueBean   GlueType = "BEAN"        // 固定模式,使用本地RegTask注册的handler,非脚本
+	GlueShell  GlueType = "GLUE_SHELL"  // Shell脚本
+	GluePython GlueType = "GLUE_PYTHON" // Python脚本
+)
+
+// glueInterpreter GLUE类型对应的解释器与文件后缀
+var glueInterpreter = map[GlueType]struct {
+	bin string
+	ext string
+}{
+	GlueShell:  {bin: "bash", ext: ".sh"},
+	GluePython: {bin: "python3", ext: ".py"},
+}
+
+// glueScript 调度中心为某个handler动态下发的脚本内容
+type glueScript struct {
+	glueType GlueType
+	source   string
+}
+
+// glueRegistry 按handler缓存调度中心下发的GLUE脚本,每次/run携带GlueSource时更新
+type glueRegistry struct {
+	mu      sync.Mutex
+	scripts map[string]glueScript
+}
+
+func newGlueRegistry() *glueRegistry {
+	return &glueRegistry{scripts: make(map[string]glueScript)}
+}
+
+// Update 更新handler对应的脚本源码,调度中心每次编辑GLUE源码后下发的都是最新全量内容
+func (g *glueRegistry) Update(handler string, glueType GlueType, source string) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.scripts[handler] = glueScript{glueType: glueType, source: source}
+}
+
+func (g *glueRegistry) get(handler string) (glueScript, bool) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	s, ok := g.scripts[handler]
+	return s, ok
+}
+
+// run 将handler对应的脚本写入临时文件并以对应解释器执行,stdout/stderr按行流式写入
+// TaskLogger,ctx超时或被取消时通过os/exec的CommandContext终止子进程
+func (g *glueRegistry) run(ctx context.Context, handler string, logger *TaskLogger) (string, error) {
+	script, ok := g.get(handler)
+	if !ok {
+		return "", fmt.Errorf("glue script not registered: %s", handler)
+	}
+	interpreter, ok := glueInterpreter[script.glueType]
+	if !ok {
+		return "", fmt.Errorf("unsupported glue type: %s", script.glueType)
+	}
+
+	f, err := ioutil.TempFile("", "xxl-glue-*"+interpreter.ext)
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(f.Name())
+	if _, err := f.WriteString(script.source); err != nil {
+		f.Close()
+		return "", err
+	}
+	f.Close()
+
+	cmd := exec.CommandContext(ctx, interpreter.bin, f.Name())
+	lw := &glueLineWriter{logger: logger}
+	cmd.Stdout = lw
+	cmd.Stderr = lw
+	err = cmd.Run()
+	lw.flush()
+	return lw.output.String(), err
+}
+
+// glueLineWriter 将子进程输出按行转发到TaskLogger,同时保留完整输出用于回传callback
+type glueLineWriter struct {
+	logger *TaskLogger
+	buf    bytes.Buffer
+	output bytes.Buffer
+}
+
+func (w *glueLineWriter) Write(p []byte) (int, error) {
+	w.output.Write(p)
+	w.buf.Write(p)
+	for {
+		line, ok := w.nextLine()
+		if !ok {
+			break
+		}
+		if w.logger != nil {
+			w.logger.Infof("%s", line)
+		}
+	}
+	return len(p), nil
+}
+
+func (w *glueLineWriter) nextLine() (string, bool) {
+	b := w.buf.Bytes()
+	idx := bytes.IndexByte(b, '\n')
+	if idx < 0 {
+		return "", false
+	}
+	line := string(w.buf.Next(idx + 1))
+	return strings.TrimRight(line, "\n"), true
+}
+
+// flush 输出残留的最后一行(没有换行符结尾)
+func (w *glueLineWriter) flush() {
+	if w.buf.Len() == 0 {
+		return
+	}
+	if w.logger != nil {
+		w.logger.Infof("%s", w.buf.String())
+	}
+	w.buf.Reset()
+}
+
+// glueTaskFunc 返回一个TaskFunc,执行时从glueRegistry中取出该handler最新的脚本并运行,
+// 使GLUE任务复用与普通TaskFunc完全相同的阻塞策略、日志注入、链路追踪与指标统计
+func (e *executor) glueTaskFunc(handler string) TaskFunc {
+	return func(cxt context.Context, param *RunReq) string {
+		logger := TaskLoggerFromContext(cxt)
+		out, err := e.glue.run(cxt, handler, logger)
+		if err != nil {
+			return fmt.Sprintf("glue execution failed: %s\n%s", err.Error(), out)
+		}
+		return out
+	}
+}
@@ -0,0 +1,352 @@
+package xxl
+
+import (
+	"bufio"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+const (
+	defaultLogMaxSize   = 10 * 1024 * 1024   // 单日志文件最大体积,超出后滚动压缩
+	defaultLogRetention = 7 * 24 * time.Hour // 默认日志保留时长
+	defaultLogIdleClose = 5 * time.Minute    // 文件连续无写入超出该时长后关闭句柄,避免fd常驻
+	maxLogLinesPerQuery = 2000               // 单次/log查询最多返回的行数,避免长任务日志一次性全量吐出
+)
+
+// RemoteLogStore 远程日志存储,实现该接口可将任务日志写入S3/OSS等远程对象存储
+type RemoteLogStore interface {
+	// Write 追加一行日志
+	Write(jobID, logID int64, line string) error
+	// LogHandler 按LogReq查询日志,可直接用作Executor.LogHandler
+	LogHandler(req *LogReq) *LogRes
+}
+
+// FileLogStore 基于本地磁盘的日志存储,按天分目录、按jobID+logID分文件,
+// 支持按体积滚动、滚动后gzip压缩归档、以及按保留时长清理历史日志
+type FileLogStore struct {
+	root      string        // 日志根目录
+	maxSize   int64         // 单文件最大体积,超出后触发滚动
+	retention time.Duration // 日志保留时长,root下修改时间超出该时长的文件(含归档与当前日志)均被删除
+	idleClose time.Duration // 文件空闲超出该时长后关闭句柄,0表示不启用空闲关闭
+
+	mu    sync.Mutex
+	files map[string]*logFile // 当前打开的日志文件,key为 jobID_logID
+	index map[int64]int64     // logID -> jobID,admin下发的LogReq只带LogID,靠这个反查落盘时的jobID
+
+	running func(jobID int64) bool // 判断jobID对应任务是否仍在运行,由UseLogStore注入
+}
+
+type logFile struct {
+	f        *os.File
+	w        *bufio.Writer
+	path     string
+	size     int64
+	lastUsed time.Time // 最近一次写入时间,用于空闲句柄回收
+}
+
+// FileLogStoreOption FileLogStore可选项
+type FileLogStoreOption func(*FileLogStore)
+
+// WithLogMaxSize 设置单文件滚动阈值,默认10MB
+func WithLogMaxSize(size int64) FileLogStoreOption {
+	return func(s *FileLogStore) {
+		s.maxSize = size
+	}
+}
+
+// WithLogRetention 设置日志保留时长,默认7天
+func WithLogRetention(d time.Duration) FileLogStoreOption {
+	return func(s *FileLogStore) {
+		s.retention = d
+	}
+}
+
+// WithLogIdleClose 设置文件句柄空闲多久后自动关闭,默认5分钟。大多数任务日志
+// 远达不到maxSize的滚动阈值,若不主动关闭空闲句柄,长期运行的执行器会耗尽fd
+func WithLogIdleClose(d time.Duration) FileLogStoreOption {
+	return func(s *FileLogStore) {
+		s.idleClose = d
+	}
+}
+
+// NewFileLogStore 创建基于文件的日志存储,root为日志根目录,如 /logs
+func NewFileLogStore(root string, opts ...FileLogStoreOption) *FileLogStore {
+	s := &FileLogStore{
+		root:      root,
+		maxSize:   defaultLogMaxSize,
+		retention: defaultLogRetention,
+		idleClose: defaultLogIdleClose,
+		files:     make(map[string]*logFile),
+		index:     make(map[int64]int64),
+	}
+	for _, o := range opts {
+		o(s)
+	}
+	go s.cleanupLoop()
+	return s
+}
+
+func logKey(jobID, logID int64) string {
+	return fmt.Sprintf("%d_%d", jobID, logID)
+}
+
+func (s *FileLogStore) dir(t time.Time) string {
+	return filepath.Join(s.root, t.Format("2006-01-02"))
+}
+
+func (s *FileLogStore) path(jobID, logID int64) string {
+	return filepath.Join(s.dir(time.Now()), fmt.Sprintf("%d_%d.log", jobID, logID))
+}
+
+// open 打开(或复用)jobID/logID对应的日志文件
+func (s *FileLogStore) open(jobID, logID int64) (*logFile, error) {
+	k := logKey(jobID, logID)
+	if lf, ok := s.files[k]; ok {
+		return lf, nil
+	}
+	s.index[logID] = jobID
+	p := s.path(jobID, logID)
+	if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+		return nil, err
+	}
+	f, err := os.OpenFile(p, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, err
+	}
+	info, _ := f.Stat()
+	lf := &logFile{f: f, w: bufio.NewWriter(f), path: p, lastUsed: time.Now()}
+	if info != nil {
+		lf.size = info.Size()
+	}
+	s.files[k] = lf
+	return lf, nil
+}
+
+// closeFile 刷新并关闭文件句柄,从files中移除,调用方需持有mu
+func (s *FileLogStore) closeFile(k string, lf *logFile) {
+	_ = lf.w.Flush()
+	_ = lf.f.Close()
+	delete(s.files, k)
+}
+
+// Write 追加一行日志,超出maxSize时滚动归档
+func (s *FileLogStore) Write(jobID, logID int64, line string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	lf, err := s.open(jobID, logID)
+	if err != nil {
+		return err
+	}
+	n, err := lf.w.WriteString(line + "\n")
+	if err != nil {
+		return err
+	}
+	if err := lf.w.Flush(); err != nil {
+		return err
+	}
+	lf.size += int64(n)
+	lf.lastUsed = time.Now()
+	if lf.size >= s.maxSize {
+		return s.rollover(logKey(jobID, logID), lf)
+	}
+	return nil
+}
+
+// rollover 压缩归档当前文件并从files中移除其句柄,下次Write时由open重新创建空文件,调用方需持有mu
+func (s *FileLogStore) rollover(k string, lf *logFile) error {
+	_ = lf.f.Close()
+	archived := lf.path + "." + time.Now().Format("150405") + ".gz"
+	if err := gzipFile(lf.path, archived); err != nil {
+		return err
+	}
+	if err := os.Remove(lf.path); err != nil {
+		return err
+	}
+	delete(s.files, k)
+	return nil
+}
+
+func gzipFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+	gw := gzip.NewWriter(out)
+	if _, err := io.Copy(gw, in); err != nil {
+		gw.Close()
+		return err
+	}
+	return gw.Close()
+}
+
+// cleanupLoop 周期性关闭空闲文件句柄(分钟级)并清理超出retention的日志(小时级)
+func (s *FileLogStore) cleanupLoop() {
+	idleTicker := time.NewTicker(time.Minute)
+	defer idleTicker.Stop()
+	retentionTicker := time.NewTicker(time.Hour)
+	defer retentionTicker.Stop()
+	for {
+		select {
+		case <-idleTicker.C:
+			s.closeIdle()
+		case <-retentionTicker.C:
+			s.cleanup()
+		}
+	}
+}
+
+// closeIdle 关闭超过idleClose时长未写入的文件句柄,避免长期运行的执行器耗尽fd。
+// 大多数任务日志体积远达不到maxSize的滚动阈值,必须有独立于滚动之外的关闭路径
+func (s *FileLogStore) closeIdle() {
+	if s.idleClose <= 0 {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for k, lf := range s.files {
+		if time.Since(lf.lastUsed) >= s.idleClose {
+			s.closeFile(k, lf)
+		}
+	}
+}
+
+// cleanup 删除超出retention的日志文件,并同步关闭/剔除files中对应的打开句柄,
+// 避免已被删除的inode上仍有存活写入方(否则Write会报告成功,但日志数据已静默丢失)
+func (s *FileLogStore) cleanup() {
+	_ = filepath.Walk(s.root, func(path string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return nil
+		}
+		if time.Since(info.ModTime()) > s.retention {
+			s.evictPath(path)
+			_ = os.Remove(path)
+		}
+		return nil
+	})
+}
+
+// evictPath 关闭files中path指向的打开句柄(若存在),供cleanup删除磁盘文件前调用
+func (s *FileLogStore) evictPath(path string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for k, lf := range s.files {
+		if lf.path == path {
+			s.closeFile(k, lf)
+			return
+		}
+	}
+}
+
+// LogHandler 按LogReq从磁盘读取日志,可直接用作Executor.LogHandler
+func (s *FileLogStore) LogHandler(req *LogReq) *LogRes {
+	jobID, ok := s.lookupJobID(req.LogID)
+	if !ok {
+		return &LogRes{Code: SuccessCode, FromLineNum: req.FromLineNum, ToLineNum: req.FromLineNum, LogContent: "", IsEnd: true}
+	}
+	p := filepath.Join(s.dir(time.UnixMilli(req.LogDateTim)), fmt.Sprintf("%d_%d.log", jobID, req.LogID))
+	f, err := os.Open(p)
+	if err != nil {
+		return &LogRes{Code: SuccessCode, FromLineNum: req.FromLineNum, ToLineNum: req.FromLineNum, LogContent: "", IsEnd: !s.isRunning(jobID)}
+	}
+	defer f.Close()
+
+	var content string
+	lineNum := 0
+	read := 0
+	capped := false
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		lineNum++
+		if lineNum < req.FromLineNum {
+			continue
+		}
+		if read >= maxLogLinesPerQuery {
+			lineNum-- //本行未计入输出,ToLineNum回退一行,下次轮询从这行重新读起
+			capped = true
+			break
+		}
+		if content != "" {
+			content += "\n"
+		}
+		content += scanner.Text()
+		read++
+	}
+	return &LogRes{
+		Code:        SuccessCode,
+		FromLineNum: req.FromLineNum,
+		ToLineNum:   lineNum,
+		LogContent:  content,
+		//capped时文件里还有未读完的内容,不能置为true;任务仍在运行时同样置为false以便
+		//admin保持轮询,只有"已读到文件末尾"且"任务已结束"时才真正没有更多日志可读
+		IsEnd: !capped && !s.isRunning(jobID),
+	}
+}
+
+// isRunning 判断jobID对应的任务是否仍在运行,未注入running回调(未通过
+// executor.UseLogStore接管)时保守返回false,即总是认为已结束
+func (s *FileLogStore) isRunning(jobID int64) bool {
+	if s.running == nil {
+		return false
+	}
+	return s.running(jobID)
+}
+
+// lookupJobID 按LogID反查落盘时记录的jobID。admin下发的LogReq只携带LogID,不含
+// JobID,而日志文件名是{jobID}_{logID}.log,必须靠Write时维护的这份反向索引才能
+// 定位到真正写入过的文件
+func (s *FileLogStore) lookupJobID(logID int64) (int64, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	jobID, ok := s.index[logID]
+	return jobID, ok
+}
+
+// TaskLogger 任务日志句柄,通过task.Ext context传递给用户TaskFunc,
+// 使其输出的日志落入该任务对应的日志存储,供调度中心按LogID查询。store为
+// RemoteLogStore接口,因此FileLogStore与用户自定义的S3/OSS等实现都可作为backing store
+type TaskLogger struct {
+	store RemoteLogStore
+	jobID int64
+	logID int64
+}
+
+type taskLoggerKey struct{}
+
+// WithTaskLogger 将TaskLogger注入context,供runTask在下发任务前调用
+func WithTaskLogger(ctx context.Context, l *TaskLogger) context.Context {
+	return context.WithValue(ctx, taskLoggerKey{}, l)
+}
+
+// TaskLoggerFromContext 从context中取出TaskLogger,未注入时返回nil
+func TaskLoggerFromContext(ctx context.Context) *TaskLogger {
+	l, _ := ctx.Value(taskLoggerKey{}).(*TaskLogger)
+	return l
+}
+
+// Infof 输出一条info级别任务日志
+func (l *TaskLogger) Infof(format string, args ...interface{}) {
+	if l == nil || l.store == nil {
+		return
+	}
+	_ = l.store.Write(l.jobID, l.logID, fmt.Sprintf("[INFO] "+format, args...))
+}
+
+// Errorf 输出一条error级别任务日志
+func (l *TaskLogger) Errorf(format string, args ...interface{}) {
+	if l == nil || l.store == nil {
+		return
+	}
+	_ = l.store.Write(l.jobID, l.logID, fmt.Sprintf("[ERROR] "+format, args...))
+}
@@ -0,0 +1,94 @@
+package xxl
+
+import (
+	"testing"
+	"time"
+)
+
+// TestFileLogStoreWriteThenRead验证Write落盘的内容能被LogHandler按LogID正确读回,
+// 覆盖之前req.JobID与真实admin LogReq格式不匹配、读出0_{logId}.log的问题
+func TestFileLogStoreWriteThenRead(t *testing.T) {
+	store := NewFileLogStore(t.TempDir())
+
+	var jobID, logID int64 = 100, 200
+	if err := store.Write(jobID, logID, "line one"); err != nil {
+		t.Fatalf("Write line one: %v", err)
+	}
+	if err := store.Write(jobID, logID, "line two"); err != nil {
+		t.Fatalf("Write line two: %v", err)
+	}
+
+	req := &LogReq{LogID: logID, LogDateTim: time.Now().UnixMilli(), FromLineNum: 1}
+	res := store.LogHandler(req)
+	if res.LogContent != "line one\nline two" {
+		t.Fatalf("unexpected log content: %q", res.LogContent)
+	}
+	if res.ToLineNum != 2 {
+		t.Fatalf("expected ToLineNum 2, got %d", res.ToLineNum)
+	}
+}
+
+// TestFileLogStoreLogHandlerUnknownLogID验证从未写入过的LogID查询时不会panic,
+// 而是返回空内容且IsEnd为true(lookupJobID查不到索引)
+func TestFileLogStoreLogHandlerUnknownLogID(t *testing.T) {
+	store := NewFileLogStore(t.TempDir())
+	res := store.LogHandler(&LogReq{LogID: 999, LogDateTim: time.Now().UnixMilli(), FromLineNum: 1})
+	if res.LogContent != "" || !res.IsEnd {
+		t.Fatalf("expected empty content and IsEnd=true for unknown LogID, got %+v", res)
+	}
+}
+
+// TestFileLogStoreIsEndTracksRunningTask验证IsEnd只有在running回调报告任务已结束后
+// 才为true,避免admin的日志查看器在任务仍在追加输出时提前停止轮询
+func TestFileLogStoreIsEndTracksRunningTask(t *testing.T) {
+	store := NewFileLogStore(t.TempDir())
+	var jobID, logID int64 = 1, 2
+	if err := store.Write(jobID, logID, "still running"); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+
+	running := true
+	store.running = func(id int64) bool { return id == jobID && running }
+
+	req := &LogReq{LogID: logID, LogDateTim: time.Now().UnixMilli(), FromLineNum: 1}
+	if res := store.LogHandler(req); res.IsEnd {
+		t.Fatalf("expected IsEnd=false while task still running")
+	}
+
+	running = false
+	if res := store.LogHandler(req); !res.IsEnd {
+		t.Fatalf("expected IsEnd=true once task finished")
+	}
+}
+
+// TestFileLogStoreLogHandlerCapsLines验证单次查询最多返回maxLogLinesPerQuery行,
+// 且capped时ToLineNum回退到最后一条实际返回的行,配合IsEnd=false供下次续读
+func TestFileLogStoreLogHandlerCapsLines(t *testing.T) {
+	store := NewFileLogStore(t.TempDir())
+	var jobID, logID int64 = 1, 2
+	total := maxLogLinesPerQuery + 10
+	for i := 0; i < total; i++ {
+		if err := store.Write(jobID, logID, "line"); err != nil {
+			t.Fatalf("Write line %d: %v", i, err)
+		}
+	}
+	store.running = func(int64) bool { return false } //任务已结束,仍应因capped而IsEnd=false
+
+	req := &LogReq{LogID: logID, LogDateTim: time.Now().UnixMilli(), FromLineNum: 1}
+	res := store.LogHandler(req)
+	if res.ToLineNum != maxLogLinesPerQuery {
+		t.Fatalf("expected ToLineNum capped at %d, got %d", maxLogLinesPerQuery, res.ToLineNum)
+	}
+	if res.IsEnd {
+		t.Fatalf("expected IsEnd=false when response was capped before EOF")
+	}
+
+	next := &LogReq{LogID: logID, LogDateTim: time.Now().UnixMilli(), FromLineNum: res.ToLineNum + 1}
+	res2 := store.LogHandler(next)
+	if res2.ToLineNum != total {
+		t.Fatalf("expected follow-up read to reach EOF at %d, got %d", total, res2.ToLineNum)
+	}
+	if !res2.IsEnd {
+		t.Fatalf("expected IsEnd=true once the capped tail is consumed and task finished")
+	}
+}
@@ -0,0 +1,105 @@
+package xxl
+
+import (
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// metrics 封装执行器对外暴露的Prometheus指标,默认注册到独立的Registry上,
+// 也可通过WithMetricsRegistry复用用户已有的抓取配置
+type metrics struct {
+	tasksStarted       *prometheus.CounterVec
+	tasksFinished      *prometheus.CounterVec
+	taskDuration       *prometheus.HistogramVec
+	tasksRunning       *prometheus.GaugeVec
+	registryHeartbeats *prometheus.CounterVec
+	callbackFailures   prometheus.Counter
+	killTotal          *prometheus.CounterVec
+}
+
+func newMetrics(reg *prometheus.Registry) *metrics {
+	m := &metrics{
+		tasksStarted: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "xxljob_tasks_started_total",
+			Help: "Total number of tasks started by handler",
+		}, []string{"handler"}),
+		tasksFinished: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "xxljob_tasks_finished_total",
+			Help: "Total number of tasks finished by handler and result",
+		}, []string{"handler", "result"}),
+		taskDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "xxljob_task_duration_seconds",
+			Help:    "Task execution duration in seconds",
+			Buckets: prometheus.DefBuckets,
+		}, []string{"handler"}),
+		tasksRunning: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "xxljob_tasks_running",
+			Help: "Number of tasks currently running by handler",
+		}, []string{"handler"}),
+		registryHeartbeats: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "xxljob_registry_heartbeats_total",
+			Help: "Total number of registry heartbeats by result",
+		}, []string{"result"}),
+		callbackFailures: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "xxljob_callback_failures_total",
+			Help: "Total number of failed /api/callback requests to the admin",
+		}),
+		killTotal: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "xxljob_kill_total",
+			Help: "Total number of kill requests by handler",
+		}, []string{"handler"}),
+	}
+	reg.MustRegister(m.tasksStarted, m.tasksFinished, m.taskDuration, m.tasksRunning, m.registryHeartbeats, m.callbackFailures, m.killTotal)
+	return m
+}
+
+// runningTask 运行中任务的调试信息,供/debug/tasks展示
+type runningTask struct {
+	JobID    int64     `json:"jobId"`
+	Handler  string    `json:"handler"`
+	StartAt  time.Time `json:"startAt"`
+	Deadline time.Time `json:"deadline,omitempty"`
+	TraceID  string    `json:"traceId"`
+}
+
+// debugTasks 列出当前正在执行的任务,包含起始时间、超时截止时间与traceID,便于on-call排查
+type debugTasks struct {
+	mu   sync.Mutex
+	data map[string]*runningTask
+}
+
+func newDebugTasks() *debugTasks {
+	return &debugTasks{data: make(map[string]*runningTask)}
+}
+
+func (d *debugTasks) add(key string, t *runningTask) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.data[key] = t
+}
+
+func (d *debugTasks) remove(key string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	delete(d.data, key)
+}
+
+func (d *debugTasks) list() []*runningTask {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	list := make([]*runningTask, 0, len(d.data))
+	for _, t := range d.data {
+		list = append(list, t)
+	}
+	return list
+}
+
+// debugTasksHandler /debug/tasks 返回当前运行任务快照的JSON
+func (e *executor) debugTasksHandler(writer http.ResponseWriter, request *http.Request) {
+	writer.Header().Set("Content-Type", "application/json;charset=UTF-8")
+	_ = json.NewEncoder(writer).Encode(e.debug.list())
+}
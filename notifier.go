@@ -0,0 +1,171 @@
+package xxl
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/smtp"
+	"net/url"
+	"sync"
+	"time"
+)
+
+// NotifyEvent 告警事件类型,用于按事件路由到不同的Notifier
+type NotifyEvent string
+
+const (
+	EventTimeout          NotifyEvent = "timeout"           // 任务执行超时
+	EventCallbackFailure  NotifyEvent = "callback_failure"  // /api/callback上报失败
+	EventExecutionFailure NotifyEvent = "execution_failure" // 任务执行结果为失败
+)
+
+// Notifier 告警通知器,message已是拼装好的完整告警内容
+type Notifier interface {
+	Notify(ctx context.Context, event NotifyEvent, message string) error
+}
+
+// alertDispatcher 组合多个Notifier做fan-out,并对同一事件+内容在窗口期内去重,
+// 避免一个反复失败(flapping)的任务把告警通道刷屏
+type alertDispatcher struct {
+	notifiers []Notifier
+	window    time.Duration
+
+	mu   sync.Mutex
+	last map[string]time.Time
+}
+
+func newAlertDispatcher(window time.Duration, notifiers ...Notifier) *alertDispatcher {
+	return &alertDispatcher{notifiers: notifiers, window: window, last: make(map[string]time.Time)}
+}
+
+// dispatch 按事件+内容去重后fan-out到所有Notifier,单个Notifier失败不影响其他Notifier
+func (d *alertDispatcher) dispatch(event NotifyEvent, message string) {
+	if d == nil || len(d.notifiers) == 0 {
+		return
+	}
+	key := string(event) + ":" + message
+	d.mu.Lock()
+	if last, ok := d.last[key]; ok && time.Since(last) < d.window {
+		d.mu.Unlock()
+		return
+	}
+	d.last[key] = time.Now()
+	d.mu.Unlock()
+
+	ctx := context.Background()
+	for _, n := range d.notifiers {
+		_ = n.Notify(ctx, event, message)
+	}
+}
+
+// DingTalkNotifier 钉钉机器人通知
+type DingTalkNotifier struct {
+	Webhook string
+	Secret  string
+}
+
+func (n *DingTalkNotifier) Notify(ctx context.Context, event NotifyEvent, message string) error {
+	if n.Webhook == "" || n.Secret == "" {
+		return fmt.Errorf("invalid dingtalk config")
+	}
+	tt := time.Now().UnixMilli()
+	sign, err := dingTalkSign(n.Secret, tt)
+	if err != nil {
+		return err
+	}
+	body, _ := json.Marshal(map[string]interface{}{
+		"msgtype": "text",
+		"text":    map[string]string{"content": message},
+	})
+	webhookURL := fmt.Sprintf("%s&timestamp=%d&sign=%s", n.Webhook, tt, url.QueryEscape(sign))
+	return postJSON(webhookURL, body)
+}
+
+// dingTalkSign 按钉钉机器人加签算法计算签名,与飞书的Sign()算法相反:
+// 飞书是 key=stringToSign、message为空,钉钉是 key=secret、message=stringToSign
+func dingTalkSign(secret string, timestampMillis int64) (string, error) {
+	stringToSign := fmt.Sprintf("%d\n%s", timestampMillis, secret)
+	h := hmac.New(sha256.New, []byte(secret))
+	if _, err := h.Write([]byte(stringToSign)); err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(h.Sum(nil)), nil
+}
+
+// FeishuNotifier 飞书机器人通知,复用feishu.go中的CardMsg/Sign逻辑
+type FeishuNotifier struct {
+	Webhook string
+	Secret  string
+	UseCard bool // true时发送interactive卡片,否则发送纯文本
+}
+
+func (n *FeishuNotifier) Notify(ctx context.Context, event NotifyEvent, message string) error {
+	isAtAll := event == EventExecutionFailure || event == EventTimeout
+	if n.UseCard {
+		return SendCardMsg(n.Webhook, n.Secret, string(event), message, isAtAll)
+	}
+	return SendTextMsg(n.Webhook, n.Secret, message, isAtAll)
+}
+
+// SlackNotifier Slack incoming webhook通知
+type SlackNotifier struct {
+	Webhook string
+}
+
+func (n *SlackNotifier) Notify(ctx context.Context, event NotifyEvent, message string) error {
+	if n.Webhook == "" {
+		return fmt.Errorf("invalid slack config")
+	}
+	body, _ := json.Marshal(map[string]string{"text": fmt.Sprintf("[%s] %s", event, message)})
+	return postJSON(n.Webhook, body)
+}
+
+// WebhookNotifier 通用JSON webhook通知,用于对接用户自有的告警网关
+type WebhookNotifier struct {
+	URL string
+}
+
+func (n *WebhookNotifier) Notify(ctx context.Context, event NotifyEvent, message string) error {
+	if n.URL == "" {
+		return fmt.Errorf("invalid webhook config")
+	}
+	body, _ := json.Marshal(map[string]string{"event": string(event), "message": message})
+	return postJSON(n.URL, body)
+}
+
+func postJSON(url string, body []byte) error {
+	request, err := http.NewRequest("POST", url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	request.Header.Set("Content-Type", "application/json;charset=UTF-8")
+	client := http.Client{Timeout: time.Second * 5}
+	resp, err := client.Do(request)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	return nil
+}
+
+// EmailNotifier 通过SMTP发送告警邮件
+type EmailNotifier struct {
+	SMTPAddr string // host:port
+	From     string
+	To       []string
+	Auth     smtp.Auth
+}
+
+func (n *EmailNotifier) Notify(ctx context.Context, event NotifyEvent, message string) error {
+	if n.SMTPAddr == "" || len(n.To) == 0 {
+		return fmt.Errorf("invalid email config")
+	}
+	subject := fmt.Sprintf("Subject: xxl-job alert [%s]\r\n", event)
+	body := fmt.Sprintf("%s\r\n\r\n%s", subject, message)
+	return smtp.SendMail(n.SMTPAddr, n.Auth, n.From, n.To, []byte(body))
+}
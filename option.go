@@ -0,0 +1,164 @@
+package xxl
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Options 执行器配置项
+type Options struct {
+	ExecutorIp    string        // 本地执行器ip,用于心跳注册,不提供则自动获取
+	ExecutorPort  string        // 本地执行器端口
+	RegistryKey   string        // 执行器名称
+	ServerAddr    string        // 调度中心地址
+	AccessToken   string        // 请求令牌
+	Timeout       time.Duration // 接口超时时间
+	NotifyWebhook string        // 报警webhook
+	NotifySecret  string        // 报警密钥
+
+	l Logger // 日志处理器
+
+	registrar      Registrar            // 执行器注册实现,默认使用XXL-Job admin HTTP注册
+	tracerProvider trace.TracerProvider // OpenTelemetry TracerProvider,未设置时使用otel全局默认值
+
+	metricsRegistry *prometheus.Registry // Prometheus指标注册表,未设置时使用独立的Registry
+	metricsPath     string               // /metrics暴露路径,默认"/metrics"
+
+	notifiers   []Notifier    // 告警通知器,为空且配置了NotifyWebhook/NotifySecret时回退到DingTalkNotifier
+	alertWindow time.Duration // 同一事件+内容的告警去重窗口,默认5分钟
+
+	maxConcurrent int // 任务执行worker池的最大并发数,<=0表示不限制(默认)
+	queueSize     int // worker池队列容量,仅在maxConcurrent>0时生效
+}
+
+// Option 设置执行器配置选项
+type Option func(o *Options)
+
+func newOptions(opts ...Option) Options {
+	opt := Options{
+		ExecutorPort: "9999",
+		Timeout:      5 * time.Second,
+		metricsPath:  "/metrics",
+		alertWindow:  5 * time.Minute,
+	}
+	for _, o := range opts {
+		o(&opt)
+	}
+	return opt
+}
+
+// ServerAddr 调度中心地址
+func ServerAddr(addr string) Option {
+	return func(o *Options) {
+		o.ServerAddr = addr
+	}
+}
+
+// AccessToken 请求令牌
+func AccessToken(token string) Option {
+	return func(o *Options) {
+		o.AccessToken = token
+	}
+}
+
+// RegistryKey 执行器名称
+func RegistryKey(key string) Option {
+	return func(o *Options) {
+		o.RegistryKey = key
+	}
+}
+
+// ExecutorIp 本地执行器ip
+func ExecutorIp(ip string) Option {
+	return func(o *Options) {
+		o.ExecutorIp = ip
+	}
+}
+
+// ExecutorPort 本地执行器端口
+func ExecutorPort(port string) Option {
+	return func(o *Options) {
+		o.ExecutorPort = port
+	}
+}
+
+// SetLogger 设置日志处理器
+func SetLogger(l Logger) Option {
+	return func(o *Options) {
+		o.l = l
+	}
+}
+
+// NotifyWebhook 报警webhook
+func NotifyWebhook(webhook string) Option {
+	return func(o *Options) {
+		o.NotifyWebhook = webhook
+	}
+}
+
+// NotifySecret 报警密钥
+func NotifySecret(secret string) Option {
+	return func(o *Options) {
+		o.NotifySecret = secret
+	}
+}
+
+// WithRegistrar 使用自定义的Registrar替代默认的XXL-Job admin HTTP注册,
+// 便于执行器运行在service mesh或统一服务发现(etcd/consul/nacos)的场景下
+func WithRegistrar(r Registrar) Option {
+	return func(o *Options) {
+		o.registrar = r
+	}
+}
+
+// WithTracerProvider 设置OpenTelemetry TracerProvider,用于生成任务执行链路的trace
+func WithTracerProvider(tp trace.TracerProvider) Option {
+	return func(o *Options) {
+		o.tracerProvider = tp
+	}
+}
+
+// WithMetricsRegistry 使用用户已有的Prometheus Registry暴露指标,便于接入现有抓取配置
+func WithMetricsRegistry(reg *prometheus.Registry) Option {
+	return func(o *Options) {
+		o.metricsRegistry = reg
+	}
+}
+
+// WithMetricsPath 设置/metrics的暴露路径,默认"/metrics"
+func WithMetricsPath(p string) Option {
+	return func(o *Options) {
+		o.metricsPath = p
+	}
+}
+
+// WithNotifiers 设置告警通知器,支持多个Notifier同时fan-out(钉钉/飞书/Slack/Webhook/邮件等)
+func WithNotifiers(notifiers ...Notifier) Option {
+	return func(o *Options) {
+		o.notifiers = notifiers
+	}
+}
+
+// WithAlertWindow 设置同一事件+内容的告警去重窗口,默认5分钟,避免flapping任务刷屏
+func WithAlertWindow(d time.Duration) Option {
+	return func(o *Options) {
+		o.alertWindow = d
+	}
+}
+
+// WithMaxConcurrent 设置任务执行worker池的最大并发数,超出并发+队列容量的调度
+// 会被/run以HTTP 429拒绝,便于调度中心重试到其他执行器。默认不限制并发
+func WithMaxConcurrent(n int) Option {
+	return func(o *Options) {
+		o.maxConcurrent = n
+	}
+}
+
+// WithQueueSize 设置worker池的排队队列容量,仅在WithMaxConcurrent(n>0)时生效
+func WithQueueSize(n int) Option {
+	return func(o *Options) {
+		o.queueSize = n
+	}
+}
@@ -0,0 +1,61 @@
+package xxl
+
+import "sync"
+
+// keyedMutex 按key分段加锁,取代单一全局锁,使不相关JobID的请求互不阻塞
+type keyedMutex struct {
+	locks sync.Map // map[string]*sync.Mutex
+}
+
+// Lock 对指定key加锁,返回的函数用于解锁,典型用法: defer m.Lock(key)()
+func (m *keyedMutex) Lock(key string) func() {
+	value, _ := m.locks.LoadOrStore(key, &sync.Mutex{})
+	mu := value.(*sync.Mutex)
+	mu.Lock()
+	return mu.Unlock
+}
+
+// workerPool 有界worker池,限制同时执行的任务数量并用有界队列承接突发流量。
+// maxConcurrent<=0时返回nil,调用方应退化为不限制并发的go fn()语义(保持向后兼容)
+type workerPool struct {
+	tasks chan func()
+}
+
+func newWorkerPool(maxConcurrent, queueSize int) *workerPool {
+	if maxConcurrent <= 0 {
+		return nil
+	}
+	if queueSize < 0 {
+		queueSize = 0
+	}
+	p := &workerPool{tasks: make(chan func(), queueSize)}
+	for i := 0; i < maxConcurrent; i++ {
+		go p.work()
+	}
+	return p
+}
+
+func (p *workerPool) work() {
+	for fn := range p.tasks {
+		fn()
+	}
+}
+
+// Submit 尝试提交任务,并发槽位与队列都已占满时返回false,调用方应拒绝该次调度
+func (p *workerPool) Submit(fn func()) bool {
+	select {
+	case p.tasks <- fn:
+		return true
+	default:
+		return false
+	}
+}
+
+// Close 关闭tasks channel,使所有阻塞在work()里range tasks的worker goroutine退出。
+// p为nil(未启用worker池)时是no-op,调用方无需判空
+func (p *workerPool) Close() {
+	if p == nil {
+		return
+	}
+	close(p.tasks)
+}
@@ -0,0 +1,52 @@
+package xxl
+
+import (
+	"strconv"
+	"sync"
+	"testing"
+)
+
+// BenchmarkGlobalMutexHighFanIn基准测试原先的单一全局锁方案:调度中心高并发
+// 下发/run时,不相关JobID的请求也会彼此串行等待
+func BenchmarkGlobalMutexHighFanIn(b *testing.B) {
+	var mu sync.Mutex
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			mu.Lock()
+			mu.Unlock()
+		}
+	})
+}
+
+// BenchmarkKeyedMutexHighFanIn基准测试按JobID分段加锁的方案,验证高fan-in下
+// 不相关JobID之间不再相互阻塞
+func BenchmarkKeyedMutexHighFanIn(b *testing.B) {
+	var km keyedMutex
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		i := 0
+		for pb.Next() {
+			key := strconv.Itoa(i % 1000)
+			unlock := km.Lock(key)
+			unlock()
+			i++
+		}
+	})
+}
+
+// BenchmarkWorkerPoolSubmit基准测试有界worker池在高并发提交下的吞吐,
+// 对应取代无界go task.Run(...)的场景
+func BenchmarkWorkerPoolSubmit(b *testing.B) {
+	pool := newWorkerPool(32, 1024)
+	var wg sync.WaitGroup
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			wg.Add(1)
+			for !pool.Submit(func() { wg.Done() }) {
+			}
+		}
+	})
+	wg.Wait()
+}
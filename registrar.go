@@ -0,0 +1,83 @@
+package xxl
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+)
+
+// Registrar 执行器注册器,负责向调度中心(或服务发现组件)注册/摘除/续约本执行器。
+// 默认实现 httpRegistrar 走XXL-Job admin的 /api/registry HTTP接口,也可替换为
+// etcd/consul/nacos等服务发现组件,便于执行器运行在service mesh或统一注册中心场景下。
+type Registrar interface {
+	// Register 注册执行器,key为注册分组下的执行器名,value为执行器地址
+	Register(ctx context.Context, key, value string) error
+	// Unregister 摘除执行器
+	Unregister(ctx context.Context, key, value string) error
+	// Watch 监听注册状态变化(租约过期、连接断开等),收到事件或通道关闭时
+	// 调用方需要重新Register。不支持watch的实现可以返回nil
+	Watch(ctx context.Context) <-chan struct{}
+}
+
+// httpRegistrar 默认实现,使用XXL-Job admin原生的HTTP注册接口
+type httpRegistrar struct {
+	e *executor
+}
+
+func newHTTPRegistrar(e *executor) Registrar {
+	return &httpRegistrar{e: e}
+}
+
+func (r *httpRegistrar) Register(ctx context.Context, key, value string) error {
+	req := &Registry{
+		RegistryGroup: "EXECUTOR",
+		RegistryKey:   key,
+		RegistryValue: value,
+	}
+	param, err := json.Marshal(req)
+	if err != nil {
+		return err
+	}
+	result, err := r.e.post("/api/registry", string(param))
+	if err != nil {
+		return err
+	}
+	defer result.Body.Close()
+	body, err := ioutil.ReadAll(result.Body)
+	if err != nil {
+		return err
+	}
+	ret := &res{}
+	if err := json.Unmarshal(body, ret); err != nil {
+		return err
+	}
+	if ret.Code != SuccessCode {
+		return fmt.Errorf("registry failed: %s", string(body))
+	}
+	return nil
+}
+
+func (r *httpRegistrar) Unregister(ctx context.Context, key, value string) error {
+	req := &Registry{
+		RegistryGroup: "EXECUTOR",
+		RegistryKey:   key,
+		RegistryValue: value,
+	}
+	param, err := json.Marshal(req)
+	if err != nil {
+		return err
+	}
+	result, err := r.e.post("/api/registryRemove", string(param))
+	if err != nil {
+		return err
+	}
+	defer result.Body.Close()
+	_, err = ioutil.ReadAll(result.Body)
+	return err
+}
+
+// Watch httpRegistrar不支持主动推送,心跳续约完全由executor.registry的定时器驱动
+func (r *httpRegistrar) Watch(ctx context.Context) <-chan struct{} {
+	return nil
+}
@@ -0,0 +1,184 @@
+package xxl
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	consulapi "github.com/hashicorp/consul/api"
+	"github.com/nacos-group/nacos-sdk-go/v2/clients/naming_client"
+	"github.com/nacos-group/nacos-sdk-go/v2/vo"
+	clientv3 "go.etcd.io/etcd/client/v3"
+)
+
+// EtcdRegistrar 基于etcd lease的Registrar实现,执行器地址以租约方式写入
+// /xxl-job/executor/{key}/{value},租约到期自动失效,由executor.registry负责续约
+type EtcdRegistrar struct {
+	cli   *clientv3.Client
+	ttl   int64
+	lease clientv3.LeaseID
+}
+
+// NewEtcdRegistrar 创建etcd注册器,ttl为租约秒数
+func NewEtcdRegistrar(cli *clientv3.Client, ttl int64) *EtcdRegistrar {
+	if ttl <= 0 {
+		ttl = 30
+	}
+	return &EtcdRegistrar{cli: cli, ttl: ttl}
+}
+
+func (r *EtcdRegistrar) Register(ctx context.Context, key, value string) error {
+	lease, err := r.cli.Grant(ctx, r.ttl)
+	if err != nil {
+		return err
+	}
+	if _, err := r.cli.Put(ctx, etcdKey(key, value), value, clientv3.WithLease(lease.ID)); err != nil {
+		return err
+	}
+	r.lease = lease.ID
+	return nil
+}
+
+func (r *EtcdRegistrar) Unregister(ctx context.Context, key, value string) error {
+	_, err := r.cli.Delete(ctx, etcdKey(key, value))
+	return err
+}
+
+// Watch 监听租约续约结果,KeepAlive通道关闭(连接丢失/租约过期)时通知调用方重新Register
+func (r *EtcdRegistrar) Watch(ctx context.Context) <-chan struct{} {
+	ch := make(chan struct{}, 1)
+	if r.lease == 0 {
+		close(ch)
+		return ch
+	}
+	keepAlive, err := r.cli.KeepAlive(ctx, r.lease)
+	if err != nil {
+		close(ch)
+		return ch
+	}
+	go func() {
+		for range keepAlive {
+			// 续约成功,无需处理
+		}
+		ch <- struct{}{}
+	}()
+	return ch
+}
+
+func etcdKey(key, value string) string {
+	return "/xxl-job/executor/" + key + "/" + value
+}
+
+// ConsulRegistrar 基于consul agent check的Registrar实现,使用TTL check做健康续约
+type ConsulRegistrar struct {
+	cli     *consulapi.Client
+	ttl     time.Duration
+	checkID string
+}
+
+// NewConsulRegistrar 创建consul注册器,ttl为健康检查TTL
+func NewConsulRegistrar(cli *consulapi.Client, ttl time.Duration) *ConsulRegistrar {
+	if ttl <= 0 {
+		ttl = 30 * time.Second
+	}
+	return &ConsulRegistrar{cli: cli, ttl: ttl}
+}
+
+func (r *ConsulRegistrar) Register(ctx context.Context, key, value string) error {
+	r.checkID = "xxl-job-executor-" + key
+	reg := &consulapi.AgentServiceRegistration{
+		ID:   r.checkID,
+		Name: key,
+		Check: &consulapi.AgentServiceCheck{
+			TTL:                            r.ttl.String(),
+			DeregisterCriticalServiceAfter: (r.ttl * 3).String(),
+		},
+	}
+	if err := r.cli.Agent().ServiceRegister(reg); err != nil {
+		return err
+	}
+	return r.cli.Agent().PassTTL("service:"+r.checkID, "registered by "+value)
+}
+
+func (r *ConsulRegistrar) Unregister(ctx context.Context, key, value string) error {
+	return r.cli.Agent().ServiceDeregister(r.checkID)
+}
+
+// Watch 每隔ttl/2上报一次健康检查,上报失败时通知调用方重新Register
+func (r *ConsulRegistrar) Watch(ctx context.Context) <-chan struct{} {
+	ch := make(chan struct{}, 1)
+	go func() {
+		ticker := time.NewTicker(r.ttl / 2)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if err := r.cli.Agent().PassTTL("service:"+r.checkID, "heartbeat"); err != nil {
+					ch <- struct{}{}
+					return
+				}
+			}
+		}
+	}()
+	return ch
+}
+
+// NacosRegistrar 基于nacos的Registrar实现
+type NacosRegistrar struct {
+	cli naming_client.INamingClient
+}
+
+// NewNacosRegistrar 创建nacos注册器
+func NewNacosRegistrar(cli naming_client.INamingClient) *NacosRegistrar {
+	return &NacosRegistrar{cli: cli}
+}
+
+func (r *NacosRegistrar) Register(ctx context.Context, key, value string) error {
+	host, port := splitAddr(value)
+	_, err := r.cli.RegisterInstance(vo.RegisterInstanceParam{
+		Ip:          host,
+		Port:        port,
+		ServiceName: key,
+		Weight:      1,
+		Enable:      true,
+		Healthy:     true,
+		Ephemeral:   true,
+	})
+	return err
+}
+
+func (r *NacosRegistrar) Unregister(ctx context.Context, key, value string) error {
+	host, port := splitAddr(value)
+	_, err := r.cli.DeregisterInstance(vo.DeregisterInstanceParam{
+		Ip:          host,
+		Port:        port,
+		ServiceName: key,
+	})
+	return err
+}
+
+// Watch nacos客户端本身维持心跳续约,这里无需额外轮询
+func (r *NacosRegistrar) Watch(ctx context.Context) <-chan struct{} {
+	return nil
+}
+
+// splitAddr 将"[scheme://]host:port"形式的地址拆分为host/port。executor.registry
+// 传入的value统一带有"http://"前缀(给HTTP/etcd注册器直接使用),Nacos只需要裸
+// 的host:port,因此这里先去掉scheme前缀再按最后一个冒号切分
+func splitAddr(addr string) (host string, port uint64) {
+	if idx := strings.Index(addr, "://"); idx >= 0 {
+		addr = addr[idx+len("://"):]
+	}
+	for i := len(addr) - 1; i >= 0; i-- {
+		if addr[i] == ':' {
+			host = addr[:i]
+			for j := i + 1; j < len(addr); j++ {
+				port = port*10 + uint64(addr[j]-'0')
+			}
+			return
+		}
+	}
+	return addr, 0
+}
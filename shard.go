@@ -0,0 +1,87 @@
+package xxl
+
+import (
+	"context"
+	"sync"
+)
+
+// 阻塞处理策略,对应调度中心下发的ExecutorBlockStrategy
+const (
+	discardLater    = "DISCARD_LATER"    // 丢弃后续调度,保留当前运行的任务
+	serialExecution = "SERIAL_EXECUTION" // 单机串行,后续调度进入FIFO队列排队等待
+)
+
+// defaultSerialQueueSize 单个JobID的串行队列默认容量,超出后新的调度被丢弃
+const defaultSerialQueueSize = 10
+
+type shardInfoKey struct{}
+
+type shardInfo struct {
+	index int
+	total int
+}
+
+// withShardInfo 将广播分片参数注入context
+func withShardInfo(ctx context.Context, index, total int) context.Context {
+	return context.WithValue(ctx, shardInfoKey{}, &shardInfo{index: index, total: total})
+}
+
+// ShardInfo 从任务context中取出调度中心下发的分片参数,非分片/广播调度时返回(0, 1)
+func ShardInfo(ctx context.Context) (index, total int) {
+	si, ok := ctx.Value(shardInfoKey{}).(*shardInfo)
+	if !ok {
+		return 0, 1
+	}
+	return si.index, si.total
+}
+
+// RegShardTask 注册一个分片任务,自动从调度参数中解析BroadcastIndex/BroadcastTotal
+// 并以类型化参数传给task,适配admin的分片广播路由策略
+func (e *executor) RegShardTask(pattern string, task func(ctx context.Context, index, total int) error) {
+	e.RegTask(pattern, func(cxt context.Context, param *RunReq) string {
+		index, total := ShardInfo(cxt)
+		if err := task(cxt, index, total); err != nil {
+			return err.Error()
+		}
+		return "success"
+	})
+}
+
+// serialQueue 单机串行(SERIAL_EXECUTION)策略下每个JobID的有界FIFO调度队列
+type serialQueue struct {
+	mu       sync.Mutex
+	capacity int
+	queue    map[string][]*RunReq
+}
+
+func newSerialQueue(capacity int) *serialQueue {
+	if capacity <= 0 {
+		capacity = defaultSerialQueueSize
+	}
+	return &serialQueue{capacity: capacity, queue: make(map[string][]*RunReq)}
+}
+
+// push 将调度参数追加到JobID对应的队列尾部,队列已满时返回false,调用方应丢弃该调度
+func (q *serialQueue) push(jobID string, param *RunReq) bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	list := q.queue[jobID]
+	if len(list) >= q.capacity {
+		return false
+	}
+	q.queue[jobID] = append(list, param)
+	return true
+}
+
+// pop 取出JobID对应队列中最早入队的调度参数
+func (q *serialQueue) pop(jobID string) (*RunReq, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	list := q.queue[jobID]
+	if len(list) == 0 {
+		return nil, false
+	}
+	next := list[0]
+	q.queue[jobID] = list[1:]
+	return next, true
+}
@@ -0,0 +1,106 @@
+package xxl
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"runtime/debug"
+	"strings"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const tracerName = "xxl-job-executor-go"
+
+// tracer 返回当前配置的TracerProvider对应的Tracer,未配置WithTracerProvider时
+// 使用otel全局默认TracerProvider(未注册SDK时为no-op,不产生额外开销)
+func (e *executor) tracer() trace.Tracer {
+	tp := e.opts.tracerProvider
+	if tp == nil {
+		tp = otel.GetTracerProvider()
+	}
+	return tp.Tracer(tracerName)
+}
+
+// extractTraceContext 从HTTP请求头中提取W3C traceparent/tracestate
+func extractTraceContext(ctx context.Context, request *http.Request) context.Context {
+	return otel.GetTextMapPropagator().Extract(ctx, propagation.HeaderCarrier(request.Header))
+}
+
+// extractTraceFromParams 兜底从RunReq.ExecutorParams中提取W3C traceparent/tracestate。
+// 原生xxl-job-admin调度/run时不会携带W3C trace头,只有调度中心本身被改造、把
+// trace信息透传进ExecutorParams时此路径才会生效;ExecutorParams可以是
+// {"traceparent":"...","tracestate":"..."}形式的JSON,也可以直接是一段裸的
+// traceparent字符串
+func extractTraceFromParams(ctx context.Context, executorParams string) context.Context {
+	executorParams = strings.TrimSpace(executorParams)
+	if executorParams == "" {
+		return ctx
+	}
+	carrier := propagation.MapCarrier{}
+	var injected struct {
+		Traceparent string `json:"traceparent"`
+		Tracestate  string `json:"tracestate"`
+	}
+	switch {
+	case json.Unmarshal([]byte(executorParams), &injected) == nil && injected.Traceparent != "":
+		carrier.Set("traceparent", injected.Traceparent)
+		if injected.Tracestate != "" {
+			carrier.Set("tracestate", injected.Tracestate)
+		}
+	case strings.HasPrefix(executorParams, "00-"):
+		carrier.Set("traceparent", executorParams)
+	default:
+		return ctx
+	}
+	return otel.GetTextMapPropagator().Extract(ctx, carrier)
+}
+
+// startTaskSpan 为一次任务下发开启根span xxl.task.run,并记录调度相关属性
+func (e *executor) startTaskSpan(ctx context.Context, param *RunReq) (context.Context, trace.Span) {
+	ctx, span := e.tracer().Start(ctx, "xxl.task.run",
+		trace.WithAttributes(
+			attribute.Int64("job.id", param.JobID),
+			attribute.String("handler", param.ExecutorHandler),
+			attribute.Int("shard.index", param.BroadcastIndex),
+			attribute.Int("shard.total", param.BroadcastTotal),
+			attribute.String("executor.blockStrategy", param.ExecutorBlockStrategy),
+			attribute.Int64("timeout", param.ExecutorTimeout),
+		),
+	)
+	return ctx, span
+}
+
+// endTaskSpan 以任务执行结果结束span,失败结果记录error状态,panic记录堆栈
+func endTaskSpan(span trace.Span, code int64, msg string, panicVal interface{}) {
+	if panicVal != nil {
+		span.SetStatus(codes.Error, fmt.Sprintf("panic: %v", panicVal))
+		span.SetAttributes(attribute.String("panic.stack", string(debug.Stack())))
+		span.End()
+		return
+	}
+	if code != SuccessCode {
+		span.SetStatus(codes.Error, msg)
+	}
+	span.End()
+}
+
+// startCallbackSpan 为/callback回调开启子span
+func (e *executor) startCallbackSpan(ctx context.Context, task *Task) (context.Context, trace.Span) {
+	return e.tracer().Start(ctx, "xxl.task.callback",
+		trace.WithAttributes(
+			attribute.Int64("job.id", task.Id),
+			attribute.String("handler", task.Name),
+		),
+	)
+}
+
+// startRegistrySpan 为注册中心调用开启子span
+func (e *executor) startRegistrySpan(ctx context.Context, action string) (context.Context, trace.Span) {
+	return e.tracer().Start(ctx, "xxl.registry."+action)
+}